@@ -16,36 +16,29 @@
 package cmds
 
 import (
-	"bufio"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/fabric8io/gofabric8/client"
 	"github.com/fabric8io/gofabric8/util"
 	"github.com/spf13/cobra"
 	"k8s.io/kubernetes/pkg/api"
+	apierrors "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
 	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
 )
 
-var (
-	removedExportedLine = []string{
-		"selfLink", "resourceVersion", "uid", "creationTimestamp",
-		"kubectl.kubernetes.io/last-applied-configuration:",
-		"control-plane.alpha.kubernetes.io/leader:",
-		"pv.kubernetes.io/",
-		"volume.beta.kubernetes.io/",
-		"volumeName"}
-)
+const reclaimPolicyFlag = "reclaim-policy"
 
 type erasePVCFlags struct {
 	cmd    *cobra.Command
 	args   []string
 	userNS string
 
-	volumeName string
+	volumeName    string
+	reclaimPolicy string
 }
 
 // NewCmdErasePVC Erase PVC https://github.com/fabric8io/gofabric8/issues/598
@@ -65,27 +58,53 @@ func NewCmdErasePVC(f cmdutil.Factory) *cobra.Command {
 				util.Fatal("We need a PVC to delete as argument.\n")
 			}
 			p.volumeName = p.args[0]
+			p.reclaimPolicy = cmd.Flags().Lookup(reclaimPolicyFlag).Value.String()
 
 			handleError(p.erasePVC(f))
 		},
 	}
 	cmd.PersistentFlags().StringP(namespaceFlag, "n", "", "The namespace where the PVC is located. Defaults to the current namespace")
+	cmd.PersistentFlags().String(reclaimPolicyFlag, "", "Temporarily flip the bound PV's reclaim policy to this value (e.g. Retain) while the PVC is recreated, restoring the original policy afterwards")
 	return cmd
 }
 
-// writeLines writes the lines to the given file.
-func writeLines(lines []string, path string) error {
-	file, err := os.Create(path)
-	if err != nil {
-		return err
+// scrubPVC clones a PVC read back from the API and clears the fields the
+// server populates, so the clone can be fed straight back in to Create.
+func scrubPVC(pvc *api.PersistentVolumeClaim) *api.PersistentVolumeClaim {
+	clone := *pvc
+	clone.ResourceVersion = ""
+	clone.UID = ""
+	clone.SelfLink = ""
+	clone.CreationTimestamp = unversioned.Time{}
+	clone.Status = api.PersistentVolumeClaimStatus{}
+	if clone.Annotations != nil {
+		annotations := map[string]string{}
+		for k, v := range clone.Annotations {
+			if isManagedPVCAnnotation(k) {
+				continue
+			}
+			annotations[k] = v
+		}
+		clone.Annotations = annotations
 	}
-	defer file.Close()
+	return &clone
+}
 
-	w := bufio.NewWriter(file)
-	for _, line := range lines {
-		fmt.Fprintln(w, line)
+// isManagedPVCAnnotation reports whether the annotation is populated by the
+// API server or a volume provisioner and must not be round-tripped verbatim.
+func isManagedPVCAnnotation(key string) bool {
+	managedPrefixes := []string{
+		"kubectl.kubernetes.io/last-applied-configuration",
+		"control-plane.alpha.kubernetes.io/leader",
+		"pv.kubernetes.io/",
+		"volume.beta.kubernetes.io/",
+	}
+	for _, prefix := range managedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
 	}
-	return w.Flush()
+	return false
 }
 
 func (p *erasePVCFlags) erasePVC(f cmdutil.Factory) (err error) {
@@ -123,73 +142,102 @@ func (p *erasePVCFlags) erasePVC(f cmdutil.Factory) (err error) {
 		}
 	}
 
-	cmd := []string{"get", "-o", "yaml", "-n", userNS, "pvc", p.volumeName}
-	output, err := runCommandWithOutput("kubectl", cmd...)
-	if err != nil {
-		util.Fatal("Error while running cmd: " + strings.Join(cmd, " ") + " Error: " + err.Error() + " Output: " + output + "\n")
-	}
+	pvcClient := c.PersistentVolumeClaims(userNS)
+	pvc, err := pvcClient.Get(p.volumeName)
+	cmdutil.CheckErr(err)
 
-	inStatus := false
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	var outputYAML []string
-	for scanner.Scan() {
-		text := scanner.Text()
-		nsLine := strings.TrimSpace(text)
-
-		stop := false
-		for _, l := range removedExportedLine {
-			if strings.HasPrefix(nsLine, l) {
-				stop = true
-			}
-		}
-		if stop {
-			continue
-		}
-		if text == "status:" {
-			inStatus = true
-			continue
-		}
+	recreate := scrubPVC(pvc)
+
+	var pv *api.PersistentVolume
+	var originalReclaimPolicy api.PersistentVolumeReclaimPolicy
+	if pvc.Spec.VolumeName != "" {
+		pv, err = c.PersistentVolumes().Get(pvc.Spec.VolumeName)
+		cmdutil.CheckErr(err)
+		originalReclaimPolicy = pv.Spec.PersistentVolumeReclaimPolicy
 
-		if inStatus && string(text[0]) != " " {
-			inStatus = false
-		} else if inStatus {
-			continue
+		if p.reclaimPolicy != "" && string(originalReclaimPolicy) != p.reclaimPolicy {
+			pv.Spec.PersistentVolumeReclaimPolicy = api.PersistentVolumeReclaimPolicy(p.reclaimPolicy)
+			pv, err = c.PersistentVolumes().Update(pv)
+			cmdutil.CheckErr(err)
+			util.Successf("Temporarily changed reclaim policy of PV %s from %s to %s\n", pv.Name, originalReclaimPolicy, p.reclaimPolicy)
 		}
-		outputYAML = append(outputYAML, text)
 	}
-	tmpfile, err := ioutil.TempFile("", "gofabric8")
+
+	err = pvcClient.Delete(p.volumeName, nil)
 	cmdutil.CheckErr(err)
 
-	err = writeLines(outputYAML, tmpfile.Name())
+	err = waitForPVCDeleted(pvcClient, p.volumeName)
 	cmdutil.CheckErr(err)
 
-	cmd = []string{"delete", "-n", userNS, "pvc", p.volumeName}
-	output, err = runCommandWithOutput("kubectl", cmd...)
-	if err != nil {
-		util.Fatal("Error while running cmd: " + strings.Join(cmd, " ") + " Error: " + err.Error() + " Output: " + output + "\n")
+	if pv != nil {
+		err = waitForPVReleased(c.PersistentVolumes(), pv.Name)
+		cmdutil.CheckErr(err)
+
+		// The volume controller only rebinds a pre-bound PVC (one with
+		// Spec.VolumeName set, as recreate is) to a PV whose claimRef is nil
+		// or already matches the new claim's UID. A Released PV still points
+		// claimRef at the deleted PVC's UID, so clear it here or the
+		// recreated PVC sits Pending against this PV forever.
+		pv, err = c.PersistentVolumes().Get(pv.Name)
+		cmdutil.CheckErr(err)
+		pv.Spec.ClaimRef = nil
+		pv, err = c.PersistentVolumes().Update(pv)
+		cmdutil.CheckErr(err)
 	}
 
-	cmd = []string{"create", "-n", userNS, "-f", tmpfile.Name()}
-	output, err = runCommandWithOutput("kubectl", cmd...)
-	if err != nil {
-		util.Fatal("Error while running cmd: " + strings.Join(cmd, " ") + " Error: " + err.Error() + " Output: " + output + "\n")
+	_, err = pvcClient.Create(recreate)
+	cmdutil.CheckErr(err)
+
+	if pv != nil && p.reclaimPolicy != "" && string(originalReclaimPolicy) != p.reclaimPolicy {
+		pv, err = c.PersistentVolumes().Get(pv.Name)
+		cmdutil.CheckErr(err)
+		pv.Spec.PersistentVolumeReclaimPolicy = originalReclaimPolicy
+		_, err = c.PersistentVolumes().Update(pv)
+		cmdutil.CheckErr(err)
+		util.Successf("Restored reclaim policy of PV %s to %s\n", pv.Name, originalReclaimPolicy)
 	}
 
 	for _, pod := range attachedpods {
-		cmd = []string{"delete", "-n", userNS, "pod", pod}
-		output, err = runCommandWithOutput("kubectl", cmd...)
-		if err != nil {
-			util.Fatal("Error while running cmd: " + strings.Join(cmd, " ") + " Error: " + err.Error() + " Output: " + output + "\n")
-		}
+		err = c.Pods(userNS).Delete(pod, nil)
+		cmdutil.CheckErr(err)
 		util.Successf("Pod %s attached to %s has been deleted.\n", pod, p.volumeName)
 	}
 
 	util.Success("Volume: " + p.volumeName + " has been recreated.\n")
-	os.Remove(tmpfile.Name())
 
 	return
 }
 
+// waitForPVCDeleted blocks until a Get of the PVC returns NotFound, so that
+// the PVC of the same name is not recreated while the old one still exists.
+func waitForPVCDeleted(pvcClient clientset.PersistentVolumeClaimInterface, name string) error {
+	return RetryAfter(1200, func() error {
+		_, err := pvcClient.Get(name)
+		if err == nil {
+			return fmt.Errorf("PVC %s still exists", name)
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}, time.Second)
+}
+
+// waitForPVReleased blocks until the bound PV is in the Released or Available phase,
+// confirming the provisioner has finished processing the PVC deletion.
+func waitForPVReleased(pvClient clientset.PersistentVolumeInterface, name string) error {
+	return RetryAfter(1200, func() error {
+		pv, err := pvClient.Get(name)
+		if err != nil {
+			return err
+		}
+		if pv.Status.Phase != api.VolumeReleased && pv.Status.Phase != api.VolumeAvailable {
+			return fmt.Errorf("PV %s is still %s", name, pv.Status.Phase)
+		}
+		return nil
+	}, time.Second)
+}
+
 // findPodsAttachedtoPVC find all pods that are attached to a certain PVC,
 // return a list of the pods name
 func findPodsAttachedtoPVC(findVolume string, c *clientset.Clientset, ns string) (ret []string, err error) {
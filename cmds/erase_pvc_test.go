@@ -0,0 +1,89 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmds
+
+import (
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+func TestIsManagedPVCAnnotation(t *testing.T) {
+	tests := []struct {
+		key     string
+		managed bool
+	}{
+		{"kubectl.kubernetes.io/last-applied-configuration", true},
+		{"control-plane.alpha.kubernetes.io/leader", true},
+		{"pv.kubernetes.io/bound-by-controller", true},
+		{"volume.beta.kubernetes.io/storage-class", true},
+		{"fabric8.io/exposeUrl", false},
+		{"my.custom/annotation", false},
+	}
+	for _, test := range tests {
+		if got := isManagedPVCAnnotation(test.key); got != test.managed {
+			t.Errorf("isManagedPVCAnnotation(%q) = %v, want %v", test.key, got, test.managed)
+		}
+	}
+}
+
+func TestScrubPVC(t *testing.T) {
+	pvc := &api.PersistentVolumeClaim{
+		ObjectMeta: api.ObjectMeta{
+			Name:              "my-pvc",
+			Namespace:         "my-ns",
+			ResourceVersion:   "123",
+			UID:               "abc-def",
+			SelfLink:          "/api/v1/namespaces/my-ns/persistentvolumeclaims/my-pvc",
+			CreationTimestamp: unversioned.Now(),
+			Annotations: map[string]string{
+				"pv.kubernetes.io/bound-by-controller": "yes",
+				"fabric8.io/exposeUrl":                 "http://my-pvc",
+			},
+		},
+		Status: api.PersistentVolumeClaimStatus{
+			Phase: api.ClaimBound,
+		},
+	}
+
+	scrubbed := scrubPVC(pvc)
+
+	if scrubbed.ResourceVersion != "" {
+		t.Errorf("ResourceVersion = %q, want empty", scrubbed.ResourceVersion)
+	}
+	if scrubbed.UID != "" {
+		t.Errorf("UID = %q, want empty", scrubbed.UID)
+	}
+	if scrubbed.SelfLink != "" {
+		t.Errorf("SelfLink = %q, want empty", scrubbed.SelfLink)
+	}
+	if !scrubbed.CreationTimestamp.IsZero() {
+		t.Errorf("CreationTimestamp = %v, want zero", scrubbed.CreationTimestamp)
+	}
+	if scrubbed.Status.Phase != "" {
+		t.Errorf("Status.Phase = %q, want empty", scrubbed.Status.Phase)
+	}
+	if _, ok := scrubbed.Annotations["pv.kubernetes.io/bound-by-controller"]; ok {
+		t.Error("scrubbed annotations still contain managed annotation pv.kubernetes.io/bound-by-controller")
+	}
+	if got := scrubbed.Annotations["fabric8.io/exposeUrl"]; got != "http://my-pvc" {
+		t.Errorf("scrubbed annotations[fabric8.io/exposeUrl] = %q, want %q", got, "http://my-pvc")
+	}
+	if scrubbed.Name != "my-pvc" || scrubbed.Namespace != "my-ns" {
+		t.Errorf("scrubbed name/namespace = %s/%s, want my-ns/my-pvc", scrubbed.Namespace, scrubbed.Name)
+	}
+}
@@ -0,0 +1,341 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fabric8io/gofabric8/client"
+	"github.com/fabric8io/gofabric8/util"
+	oclient "github.com/openshift/origin/pkg/client"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+	"github.com/spf13/cobra"
+	kubeApi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/intstr"
+)
+
+const (
+	exposeLabel = "fabric8.io/expose"
+
+	allNamespacesFlag = "all-namespaces"
+	tlsFlag           = "tls"
+	ingressClassFlag  = "ingress-class"
+	domainFlag        = "domain"
+
+	defaultIngressClass = "nginx"
+)
+
+type exposeFlags struct {
+	cmd  *cobra.Command
+	args []string
+
+	allNamespaces bool
+	namespace     string
+	tls           string
+	ingressClass  string
+	domain        string
+}
+
+// NewCmdExpose materialises fabric8.io/exposeUrl annotations itself for
+// services annotated fabric8.io/expose=true, so `gofabric8 service` keeps
+// working without a separate exposecontroller deployment watching services.
+func NewCmdExpose(f cmdutil.Factory) *cobra.Command {
+	p := &exposeFlags{}
+	cmd := &cobra.Command{
+		Use:   "expose",
+		Short: "Expose services annotated fabric8.io/expose=true",
+		Long:  `Reconciles the fabric8.io/exposeUrl annotation on services annotated fabric8.io/expose=true by creating a Route, Ingress or NodePort as appropriate for the cluster`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			p.args = args
+			p.allNamespaces = cmd.Flags().Lookup(allNamespacesFlag).Value.String() == "true"
+			p.namespace = cmd.Flags().Lookup(namespaceFlag).Value.String()
+			p.tls = cmd.Flags().Lookup(tlsFlag).Value.String()
+			p.ingressClass = cmd.Flags().Lookup(ingressClassFlag).Value.String()
+			p.domain = cmd.Flags().Lookup(domainFlag).Value.String()
+
+			if p.namespace == "" {
+				p.namespace, _, _ = f.DefaultNamespace()
+			}
+
+			handleError(p.expose(f))
+		},
+	}
+	cmd.PersistentFlags().StringP(namespaceFlag, "n", "", "The namespace to expose services in. Defaults to the current namespace")
+	cmd.PersistentFlags().Bool(allNamespacesFlag, false, "Expose annotated services in every namespace")
+	cmd.PersistentFlags().String(tlsFlag, "edge", "The Route TLS termination to use on OpenShift: edge, passthrough or reencrypt")
+	cmd.PersistentFlags().String(ingressClassFlag, defaultIngressClass, "The ingress class to use for the created Ingress on Kubernetes")
+	cmd.PersistentFlags().String(domainFlag, "", "The domain to template the hostname from on Kubernetes, e.g. <service>.<namespace>.<domain>")
+	return cmd
+}
+
+func (p *exposeFlags) expose(f cmdutil.Factory) error {
+	c, cfg := client.NewClient(f)
+	oc, _ := client.NewOpenShiftClient(cfg)
+
+	namespaces := []string{p.namespace}
+	if p.allNamespaces {
+		nsList, err := c.Namespaces().List(kubeApi.ListOptions{})
+		if err != nil {
+			return err
+		}
+		namespaces = nil
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	typeOfMaster := util.TypeOfMaster(c)
+	for _, ns := range namespaces {
+		svcs, err := c.Services(ns).List(kubeApi.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, svc := range svcs.Items {
+			if svc.ObjectMeta.Labels[exposeLabel] != "true" && svc.ObjectMeta.Annotations[exposeLabel] != "true" {
+				continue
+			}
+			url, err := p.exposeService(c, oc, typeOfMaster, &svc)
+			if err != nil {
+				util.Errorf("Failed to expose service %s in namespace %s: %v\n", svc.Name, ns, err)
+				continue
+			}
+			if err := p.setExposeURLAnnotation(c, ns, svc.Name, url); err != nil {
+				return err
+			}
+			util.Successf("Exposed service %s in namespace %s at %s\n", svc.Name, ns, url)
+		}
+	}
+	return nil
+}
+
+func (p *exposeFlags) exposeService(c *clientset.Clientset, oc *oclient.Client, typeOfMaster util.MasterType, svc *kubeApi.Service) (string, error) {
+	switch {
+	case typeOfMaster == util.OpenShift:
+		return p.exposeViaRoute(oc, svc)
+	case p.domain != "":
+		return p.exposeViaIngress(c, svc)
+	default:
+		return p.exposeViaNodePort(c, svc)
+	}
+}
+
+// exposeViaRoute creates or updates an edge-terminated (by default) Route
+// pointing at the service, and waits for its host to be admitted.
+func (p *exposeFlags) exposeViaRoute(oc *oclient.Client, svc *kubeApi.Service) (string, error) {
+	routes := oc.Routes(svc.Namespace)
+	route, err := routes.Get(svc.Name)
+	if err != nil {
+		route = &routeapi.Route{
+			ObjectMeta: kubeApi.ObjectMeta{Name: svc.Name, Namespace: svc.Namespace},
+			Spec: routeapi.RouteSpec{
+				To: routeapi.RouteTargetReference{Kind: "Service", Name: svc.Name},
+				TLS: &routeapi.TLSConfig{
+					Termination: routeapi.TLSTerminationType(p.tls),
+				},
+			},
+		}
+		route, err = routes.Create(route)
+		if err != nil {
+			return "", err
+		}
+	} else if route.Spec.TLS == nil || route.Spec.TLS.Termination != routeapi.TLSTerminationType(p.tls) {
+		route.Spec.To = routeapi.RouteTargetReference{Kind: "Service", Name: svc.Name}
+		route.Spec.TLS = &routeapi.TLSConfig{Termination: routeapi.TLSTerminationType(p.tls)}
+		route, err = routes.Update(route)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error {
+		route, err = routes.Get(svc.Name)
+		if err != nil {
+			return err
+		}
+		if len(route.Status.Ingress) == 0 || route.Status.Ingress[0].Host == "" {
+			return fmt.Errorf("route %s has not been admitted yet", svc.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "http"
+	if route.Spec.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, route.Status.Ingress[0].Host), nil
+}
+
+// exposeViaIngress creates or updates an Ingress for the service, hostnamed
+// from --domain, and waits for the ingress controller to assign an address.
+func (p *exposeFlags) exposeViaIngress(c *clientset.Clientset, svc *kubeApi.Service) (string, error) {
+	if len(svc.Spec.Ports) == 0 {
+		return "", fmt.Errorf("service %s declares no ports to expose", svc.Name)
+	}
+	host := fmt.Sprintf("%s.%s.%s", svc.Name, svc.Namespace, p.domain)
+
+	ingresses := c.Extensions().Ingresses(svc.Namespace)
+	ingress, err := ingresses.Get(svc.Name)
+	if err != nil {
+		ingress = &extensions.Ingress{
+			ObjectMeta: kubeApi.ObjectMeta{
+				Name:      svc.Name,
+				Namespace: svc.Namespace,
+				Annotations: map[string]string{
+					"kubernetes.io/ingress.class": p.ingressClass,
+				},
+			},
+			Spec: extensions.IngressSpec{
+				Rules: []extensions.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: extensions.IngressRuleValue{
+							HTTP: &extensions.HTTPIngressRuleValue{
+								Paths: []extensions.HTTPIngressPath{
+									{
+										Backend: extensions.IngressBackend{
+											ServiceName: svc.Name,
+											ServicePort: intstr.FromInt(int(svc.Spec.Ports[0].Port)),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		if _, err := ingresses.Create(ingress); err != nil {
+			return "", err
+		}
+	} else if ingress.ObjectMeta.Annotations["kubernetes.io/ingress.class"] != p.ingressClass ||
+		len(ingress.Spec.Rules) == 0 || ingress.Spec.Rules[0].Host != host {
+		if ingress.ObjectMeta.Annotations == nil {
+			ingress.ObjectMeta.Annotations = map[string]string{}
+		}
+		ingress.ObjectMeta.Annotations["kubernetes.io/ingress.class"] = p.ingressClass
+		ingress.Spec.Rules = []extensions.IngressRule{
+			{
+				Host: host,
+				IngressRuleValue: extensions.IngressRuleValue{
+					HTTP: &extensions.HTTPIngressRuleValue{
+						Paths: []extensions.HTTPIngressPath{
+							{
+								Backend: extensions.IngressBackend{
+									ServiceName: svc.Name,
+									ServicePort: intstr.FromInt(int(svc.Spec.Ports[0].Port)),
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		if _, err := ingresses.Update(ingress); err != nil {
+			return "", err
+		}
+	}
+
+	err = RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error {
+		ingress, err = ingresses.Get(svc.Name)
+		if err != nil {
+			return err
+		}
+		if len(ingress.Status.LoadBalancer.Ingress) == 0 {
+			return fmt.Errorf("ingress %s has no address yet", svc.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("http://%s", host), nil
+}
+
+// exposeViaNodePort flips the service to type NodePort, falling back to
+// <node-ip>:<port> when there's no ingress/router in front of the cluster.
+func (p *exposeFlags) exposeViaNodePort(c *clientset.Clientset, svc *kubeApi.Service) (string, error) {
+	if svc.Spec.Type != kubeApi.ServiceTypeNodePort {
+		svc.Spec.Type = kubeApi.ServiceTypeNodePort
+		updated, err := c.Services(svc.Namespace).Update(svc)
+		if err != nil {
+			return "", err
+		}
+		svc = updated
+	}
+
+	nodes, err := c.Nodes().List(kubeApi.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	if len(nodes.Items) == 0 {
+		return "", fmt.Errorf("no nodes found to resolve a node IP for service %s", svc.Name)
+	}
+	nodeIP := ""
+	for _, address := range nodes.Items[0].Status.Addresses {
+		if address.Type == kubeApi.NodeExternalIP || address.Type == kubeApi.NodeInternalIP {
+			nodeIP = address.Address
+			break
+		}
+	}
+	if nodeIP == "" {
+		return "", fmt.Errorf("could not resolve an IP address for node %s", nodes.Items[0].Name)
+	}
+
+	nodePort := int32(0)
+	err = RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error {
+		current, err := c.Services(svc.Namespace).Get(svc.Name)
+		if err != nil {
+			return err
+		}
+		for _, port := range current.Spec.Ports {
+			if port.NodePort != 0 {
+				nodePort = port.NodePort
+				return nil
+			}
+		}
+		return fmt.Errorf("service %s has no node port assigned yet", svc.Name)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%d", nodeIP, nodePort), nil
+}
+
+// setExposeURLAnnotation writes the resolved URL back onto the service so
+// that `gofabric8 service`/`open` keep working without change.
+func (p *exposeFlags) setExposeURLAnnotation(c *clientset.Clientset, ns string, serviceName string, url string) error {
+	svcClient := c.Services(ns)
+	svc, err := svcClient.Get(serviceName)
+	if err != nil {
+		return err
+	}
+	if svc.ObjectMeta.Annotations == nil {
+		svc.ObjectMeta.Annotations = map[string]string{}
+	}
+	svc.ObjectMeta.Annotations[exposeURLAnnotation] = url
+	_, err = svcClient.Update(svc)
+	return err
+}
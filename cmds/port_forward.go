@@ -0,0 +1,161 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmds
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/fabric8io/gofabric8/client"
+	"github.com/fabric8io/gofabric8/util"
+	"github.com/spf13/cobra"
+	kubeApi "k8s.io/kubernetes/pkg/api"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset"
+	"k8s.io/kubernetes/pkg/client/unversioned/portforward"
+	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/httpstream/spdy"
+)
+
+type portForwardFlags struct {
+	cmd  *cobra.Command
+	args []string
+
+	namespace string
+}
+
+// NewCmdPortForward tunnels one or more local ports to a ready pod behind a
+// service via SPDY, for clusters that have no ingress/router in front of them.
+// Credits: https://github.com/kubernetes/kubernetes/blob/v1.4.0/pkg/kubectl/cmd/portforward.go
+func NewCmdPortForward(f cmdutil.Factory) *cobra.Command {
+	p := &portForwardFlags{}
+	cmd := &cobra.Command{
+		Use:   "port-forward [service] [local:remote]...",
+		Short: "Forward one or more local ports to a pod behind a service",
+		Long:  `Forward one or more local ports to a pod behind a service, picking a ready pod via the service's endpoints`,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			p.args = args
+			p.namespace = cmd.Flags().Lookup(namespaceCommandFlag).Value.String()
+
+			if len(p.args) < 1 {
+				util.Fatal("We need a service name as the first argument.\n")
+			}
+			if p.namespace == "" {
+				p.namespace, _, _ = f.DefaultNamespace()
+			}
+
+			handleError(p.portForward(f))
+		},
+	}
+	cmd.PersistentFlags().StringP(namespaceCommandFlag, "n", "", "The namespace of the service. Defaults to the current namespace")
+	return cmd
+}
+
+func (p *portForwardFlags) portForward(f cmdutil.Factory) error {
+	serviceName := p.args[0]
+	ports := p.args[1:]
+
+	c, cfg := client.NewClient(f)
+
+	pod, err := readyPodForService(p.namespace, serviceName, c)
+	if err != nil {
+		return err
+	}
+
+	if len(ports) == 0 {
+		ports, err = defaultPortsForPod(pod)
+		if err != nil {
+			return err
+		}
+	}
+
+	req := c.RESTClient.Post().
+		Resource("pods").
+		Namespace(p.namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return err
+	}
+	dialer := spdy.NewSpdyRoundTripper(transport, upgrader, &http.Client{}, req.URL())
+
+	stopChan := make(chan struct{}, 1)
+	readyChan := make(chan struct{})
+	fw, err := portforward.New(dialer, ports, stopChan, readyChan, os.Stdout, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(stopChan)
+	}()
+
+	go func() {
+		<-readyChan
+		for _, portPair := range ports {
+			local := strings.SplitN(portPair, ":", 2)[0]
+			util.Successf("Forwarding to %s: http://127.0.0.1:%s\n", serviceName, local)
+		}
+	}()
+
+	return fw.ForwardPorts()
+}
+
+// readyPodForService resolves a service to one of its ready backing pods via
+// the endpoints object, reusing the same readiness check as `service`/`open`.
+func readyPodForService(ns string, serviceName string, c *clientset.Clientset) (*kubeApi.Pod, error) {
+	endpoints, err := c.Endpoints(ns).Get(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckEndpointReady(endpoints); err != nil {
+		return nil, err
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+				continue
+			}
+			return c.Pods(ns).Get(address.TargetRef.Name)
+		}
+	}
+	return nil, fmt.Errorf("no ready pod found behind service %s in namespace %s", serviceName, ns)
+}
+
+// defaultPortsForPod forwards every container port declared on the pod,
+// mapping each to the same local port, when the user didn't specify any.
+func defaultPortsForPod(pod *kubeApi.Pod) ([]string, error) {
+	var ports []string
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			ports = append(ports, strconv.Itoa(int(port.ContainerPort)))
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("pod %s declares no container ports to forward", pod.Name)
+	}
+	return ports, nil
+}
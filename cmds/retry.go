@@ -0,0 +1,111 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RootContext is cancelled by the signal handler installed in main, so that
+// long Check*/WaitFor* retry loops started anywhere in cmds can be
+// interrupted cleanly with Ctrl-C instead of running all 1200 attempts.
+var RootContext = context.Background()
+
+// BackoffOpts configures RetryWithBackoff's exponential-backoff-with-jitter
+// schedule. The zero value is not usable; use DefaultBackoffOpts as a base.
+type BackoffOpts struct {
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+	MaxElapsed          time.Duration
+}
+
+// DefaultBackoffOpts mirrors the cadence of the old fixed 1200x10s loop for
+// callers that don't need a tighter or looser schedule.
+var DefaultBackoffOpts = BackoffOpts{
+	InitialInterval:     time.Second,
+	MaxInterval:         10 * time.Second,
+	Multiplier:          1.5,
+	RandomizationFactor: 0.3,
+	MaxElapsed:          20 * time.Minute,
+}
+
+// RetryWithBackoff calls fn until it succeeds, ctx is done, or MaxElapsed
+// has passed, sleeping an exponentially growing interval between attempts,
+// randomised by up to RandomizationFactor of the interval either way.
+// On failure it returns the last error fn returned plus the attempt count,
+// rather than every error collected along the way.
+func RetryWithBackoff(ctx context.Context, opts BackoffOpts, fn func(ctx context.Context) error) error {
+	interval := opts.InitialInterval
+	start := time.Now()
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		lastErr = fn(ctx)
+		if lastErr == nil {
+			return nil
+		}
+
+		if opts.MaxElapsed > 0 && time.Since(start) > opts.MaxElapsed {
+			return fmt.Errorf("gave up after %d attempts, last error: %v", attempt, lastErr)
+		}
+
+		sleep := interval
+		if opts.RandomizationFactor > 0 {
+			delta := opts.RandomizationFactor * float64(interval)
+			sleep = time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cancelled after %d attempts, last error: %v", attempt, lastErr)
+		case <-time.After(sleep):
+		}
+
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+		if opts.MaxInterval > 0 && interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+// Retry calls callback up to attempts times with no delay between attempts.
+func Retry(attempts int, callback func() error) (err error) {
+	return RetryAfter(attempts, callback, 0)
+}
+
+// RetryAfter calls callback up to attempts times, sleeping d between each,
+// and is honoured by RootContext so a Ctrl-C still interrupts it cleanly.
+// It's kept as a thin wrapper for compatibility with existing callers that
+// want a fixed attempt count rather than RetryWithBackoff's growing delay.
+func RetryAfter(attempts int, callback func() error, d time.Duration) (err error) {
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = callback()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-RootContext.Done():
+			return fmt.Errorf("cancelled after %d attempts, last error: %v", attempt, err)
+		case <-time.After(d):
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts, last error: %v", attempts, err)
+}
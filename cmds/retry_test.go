@@ -0,0 +1,114 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryWithBackoffSucceedsEventually(t *testing.T) {
+	attempts := 0
+	opts := BackoffOpts{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     4 * time.Millisecond,
+		Multiplier:      2,
+	}
+	err := RetryWithBackoff(context.Background(), opts, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("not ready yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestRetryWithBackoffRespectsMaxElapsed(t *testing.T) {
+	opts := BackoffOpts{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     2 * time.Millisecond,
+		Multiplier:      2,
+		MaxElapsed:      5 * time.Millisecond,
+	}
+	start := time.Now()
+	err := RetryWithBackoff(context.Background(), opts, func(ctx context.Context) error {
+		return errors.New("always fails")
+	})
+	if err == nil {
+		t.Fatal("expected an error once MaxElapsed is exceeded, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v to give up on a 5ms MaxElapsed budget", elapsed)
+	}
+}
+
+func TestRetryWithBackoffRespectsContextCancellation(t *testing.T) {
+	opts := BackoffOpts{
+		InitialInterval: 50 * time.Millisecond,
+		MaxInterval:     50 * time.Millisecond,
+		Multiplier:      1,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := RetryWithBackoff(ctx, opts, func(ctx context.Context) error {
+		return errors.New("always fails")
+	})
+	if err == nil {
+		t.Fatal("expected an error when the context is already cancelled, got nil")
+	}
+}
+
+func TestRetryWithBackoffCapsIntervalAtMaxInterval(t *testing.T) {
+	var sleeps []time.Duration
+	lastCall := time.Now()
+	opts := BackoffOpts{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     3 * time.Millisecond,
+		Multiplier:      4,
+	}
+	attempts := 0
+	_ = RetryWithBackoff(context.Background(), opts, func(ctx context.Context) error {
+		now := time.Now()
+		if attempts > 0 {
+			sleeps = append(sleeps, now.Sub(lastCall))
+		}
+		lastCall = now
+		attempts++
+		if attempts >= 6 {
+			return nil
+		}
+		return errors.New("not ready yet")
+	})
+	if len(sleeps) != 5 {
+		t.Fatalf("recorded %d sleeps, want 5", len(sleeps))
+	}
+	// Uncapped, the sleeps would grow 1ms, 4ms, 16ms, 64ms, 256ms; capped
+	// at 3ms they stay far below 20ms even allowing for scheduler slack.
+	for i, sleep := range sleeps {
+		if sleep > 20*time.Millisecond {
+			t.Errorf("sleep[%d] = %v, want it capped near MaxInterval (3ms)", i, sleep)
+		}
+	}
+}
@@ -16,11 +16,10 @@
 package cmds
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/fabric8io/gofabric8/client"
 	"github.com/fabric8io/gofabric8/util"
@@ -58,7 +57,7 @@ func NewCmdService(f cmdutil.Factory) *cobra.Command {
 			printURL := cmd.Flags().Lookup(urlCommandFlag).Value.String() == "true"
 			retry := cmd.Flags().Lookup(retryFlag).Value.String() == "true"
 			if len(args) == 1 {
-				openService(ns, args[0], c, printURL, retry)
+				openService(f, ns, args[0], c, printURL, retry)
 			} else {
 				util.Fatalf("Please choose a service, found %v arguments\n", len(args))
 			}
@@ -70,9 +69,14 @@ func NewCmdService(f cmdutil.Factory) *cobra.Command {
 	return cmd
 }
 
-func openService(ns string, serviceName string, c *clientset.Clientset, printURL bool, retry bool) {
-	if retry {
-		if err := RetryAfter(1200, func() error { return CheckExternalService(ns, serviceName, c) }, 10*time.Second); err != nil {
+func openService(f cmdutil.Factory, ns string, serviceName string, c *clientset.Clientset, printURL bool, retry bool) {
+	// Only wait on CheckExternalService when the service already carries an
+	// exposeUrl annotation: that's the only case it can ever succeed. On a
+	// bare minikube/minishift with no exposecontroller the annotation never
+	// appears, so retrying here would just burn the full backoff budget
+	// before ever reaching the port-forward fallback below.
+	if svc, err := c.Services(ns).Get(serviceName); retry && err == nil && svc.ObjectMeta.Annotations[exposeURLAnnotation] != "" {
+		if err := RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error { return CheckExternalService(ns, serviceName, c) }); err != nil {
 			util.Errorf("Could not find finalized endpoint being pointed to by %s: %v", serviceName, err)
 			os.Exit(1)
 		}
@@ -87,6 +91,16 @@ func openService(ns string, serviceName string, c *clientset.Clientset, printURL
 
 			url := service.ObjectMeta.Annotations[exposeURLAnnotation]
 
+			if url == "" {
+				// No exposecontroller/ingress/router has populated an exposeUrl
+				// annotation, so fall back to tunneling straight to the pod.
+				util.Warnf("No %s annotation on service %s, falling back to port-forward\n", exposeURLAnnotation, serviceName)
+				p := &portForwardFlags{namespace: ns, args: []string{serviceName}}
+				handleError(p.portForward(f))
+				found = true
+				break
+			}
+
 			if printURL {
 				util.Successf("%s\n", url)
 			} else {
@@ -157,7 +171,7 @@ func FindServiceInEveryNamespace(serviceName string, c *clientset.Clientset, oc
 func FindServiceURL(ns string, serviceName string, c *clientset.Clientset, retry bool) string {
 	answer := ""
 	if retry {
-		if err := RetryAfter(1200, func() error { return CheckServiceExists(ns, serviceName, c) }, 10*time.Second); err != nil {
+		if err := RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error { return CheckServiceExists(ns, serviceName, c) }); err != nil {
 			util.Errorf("Could not find finalized endpoint being pointed to by %s: %v", serviceName, err)
 			os.Exit(1)
 		}
@@ -189,7 +203,7 @@ func GetServiceURL(ns string, serviceName string, c *clientset.Clientset) string
 
 // WaitForService waits for a service and its endpoint to be ready
 func WaitForService(ns string, serviceName string, c *clientset.Clientset) {
-	if err := RetryAfter(1200, func() error { return CheckService(ns, serviceName, c) }, 10*time.Second); err != nil {
+	if err := RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error { return CheckService(ns, serviceName, c) }); err != nil {
 		util.Errorf("Could not find finalized endpoint being pointed to by %s: %v", serviceName, err)
 		os.Exit(1)
 	}
@@ -268,7 +282,7 @@ func CheckEndpointReady(endpoint *kubeApi.Endpoints) error {
 //WaitForExternalIPAddress will wait for loadbalancers to update the service and return it's external ip address
 func WaitForExternalIPAddress(ns string, serviceName string, c *clientset.Clientset) (address string, err error) {
 
-	if err := RetryAfter(1200, func() error { return HasExternalIP(ns, serviceName, c) }, 10*time.Second); err != nil {
+	if err := RetryWithBackoff(RootContext, DefaultBackoffOpts, func(ctx context.Context) error { return HasExternalIP(ns, serviceName, c) }); err != nil {
 		util.Errorf("Could not find external IP for %s: %v", serviceName, err)
 		os.Exit(1)
 	}
@@ -293,42 +307,3 @@ func HasExternalIP(ns string, serviceName string, c *clientset.Clientset) error
 	}
 	return fmt.Errorf("Service has no external ip or hostname yet\n")
 }
-
-func Retry(attempts int, callback func() error) (err error) {
-	return RetryAfter(attempts, callback, 0)
-}
-
-func RetryAfter(attempts int, callback func() error, d time.Duration) (err error) {
-	m := MultiError{}
-	for i := 0; i < attempts; i++ {
-		err = callback()
-		if err == nil {
-			return nil
-		}
-		m.Collect(err)
-		time.Sleep(d)
-	}
-	return m.ToError()
-}
-
-type MultiError struct {
-	Errors []error
-}
-
-func (m *MultiError) Collect(err error) {
-	if err != nil {
-		m.Errors = append(m.Errors, err)
-	}
-}
-
-func (m MultiError) ToError() error {
-	if len(m.Errors) == 0 {
-		return nil
-	}
-
-	errStrings := []string{}
-	for _, err := range m.Errors {
-		errStrings = append(errStrings, err.Error())
-	}
-	return fmt.Errorf(strings.Join(errStrings, "\n"))
-}
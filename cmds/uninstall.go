@@ -1,18 +1,30 @@
 package cmds
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"encoding/json"
 
+	"github.com/fabric8io/gofabric8/client"
 	"github.com/fabric8io/gofabric8/util"
 	"github.com/spf13/cobra"
+	"k8s.io/kubernetes/pkg/api"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset"
+	"k8s.io/kubernetes/pkg/client/restclient"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
 )
 
+const (
+	tenantServiceFlag = "tenant-service"
+	dryRunFlag        = "dry-run"
+	yesFlag           = "yes"
+
+	defaultTenantService = "f8tenant"
+)
+
 type Namespace struct {
 	Name string
 	Type string
@@ -26,7 +38,9 @@ type uninstallFlags struct {
 	cmd  *cobra.Command
 	args []string
 
-	confirm bool
+	confirm       bool
+	tenantService string
+	dryRun        bool
 }
 
 func NewCmdUninstall(f cmdutil.Factory) *cobra.Command {
@@ -40,15 +54,31 @@ func NewCmdUninstall(f cmdutil.Factory) *cobra.Command {
 			if cmd.Flags().Lookup(yesFlag).Value.String() == "true" {
 				p.confirm = true
 			}
+			p.tenantService = cmd.Flags().Lookup(tenantServiceFlag).Value.String()
+			p.dryRun = cmd.Flags().Lookup(dryRunFlag).Value.String() == "true"
 			handleError(p.uninstall(f))
 		},
 	}
+	cmd.PersistentFlags().String(tenantServiceFlag, defaultTenantService, "The name of the tenant service to query for the list of tenant namespaces")
+	cmd.PersistentFlags().Bool(dryRunFlag, false, "Print the namespaces that would be deleted without deleting them")
 	return cmd
 }
 
 func (p *uninstallFlags) uninstall(f cmdutil.Factory) error {
-	url := "http://f8tenant-fabric8.openshift.chmouel.com/api/tenant/all"
-	if !p.confirm {
+	c, cfg := client.NewClient(f)
+	oc, _ := client.NewOpenShiftClient(cfg)
+
+	var tenantURL string
+	err := RetryAfter(5, func() error {
+		var err error
+		tenantURL, err = FindServiceInEveryNamespace(p.tenantService, c, oc, f)
+		return err
+	}, 2*time.Second)
+	if err != nil {
+		return fmt.Errorf("Could not find the %s service to list tenants: %v", p.tenantService, err)
+	}
+
+	if !p.dryRun && !p.confirm {
 		confirm := ""
 		util.Warn("WARNING this command will delete all resources from *ALL TENANTS*\n")
 		util.Warn("\nContinue [y/N]: ")
@@ -58,41 +88,75 @@ func (p *uninstallFlags) uninstall(f cmdutil.Factory) error {
 			return nil
 		}
 	}
-	cfg, err := f.ClientConfig()
-	cmdutil.CheckErr(err)
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
-	cmdutil.CheckErr(err)
+	transport, err := restclient.TransportFor(cfg)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Transport: transport}
 
-	req.Header.Set("Authorization", "Bearer "+cfg.BearerToken)
-	res, err := client.Do(req)
+	req, err := http.NewRequest("GET", tenantURL+"/api/tenant/all", nil)
 	cmdutil.CheckErr(err)
 
-	body, err := ioutil.ReadAll(res.Body)
-	defer res.Body.Close()
-	cmdutil.CheckErr(err)
+	var body []byte
+	err = RetryAfter(5, func() error {
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
 
-	if res.StatusCode >= 300 {
-		cmdutil.CheckErr(errors.New(fmt.Sprintf("Failed to GET all tenants from init-tenant service on %s got status code to: %d output: %s", url, res.StatusCode, string(body))))
-	}
+		body, err = ioutil.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode >= 300 {
+			return fmt.Errorf("Failed to GET all tenants from %s got status code to: %d output: %s", tenantURL, res.StatusCode, string(body))
+		}
+		return nil
+	}, 2*time.Second)
+	cmdutil.CheckErr(err)
 
 	var alltenants AllTenantJson
-	gofabric8Cli := "gofabric8"
-
 	json.Unmarshal(body, &alltenants)
 
 	for _, value := range alltenants.Namespaces {
-		if value.Type == "user" {
-			commands := []string{"delete", "tenant", "-t", value.Name, "--as=system:admin"}
-			if p.confirm {
-				commands = append(commands, "-y")
-			}
+		if value.Type != "user" {
+			continue
+		}
 
-			err = runCommand(gofabric8Cli, commands...)
-			cmdutil.CheckErr(err)
+		if p.dryRun {
+			util.Successf("Would delete tenant namespace %s\n", value.Name)
+			continue
+		}
+
+		err := RetryAfter(5, func() error {
+			if err := eraseNamespacePVCs(c, value.Name); err != nil {
+				return err
+			}
+			return oc.Projects().Delete(value.Name)
+		}, 2*time.Second)
+		if err != nil {
+			return err
 		}
+		util.Successf("Deleted tenant namespace %s\n", value.Name)
 	}
 
 	return nil
 }
+
+// eraseNamespacePVCs deletes every PVC in the namespace ahead of the project
+// delete, reusing the same typed client used by erase-pvc.
+func eraseNamespacePVCs(c *clientset.Clientset, ns string) error {
+	pvcClient := c.PersistentVolumeClaims(ns)
+	pvcs, err := pvcClient.List(api.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs.Items {
+		if err := pvcClient.Delete(pvc.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -16,14 +16,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/blang/semver"
 	commands "github.com/fabric8io/gofabric8/cmds"
+	"github.com/fabric8io/gofabric8/pkg/selfupdate"
 	"github.com/fabric8io/gofabric8/util"
 	"github.com/fabric8io/gofabric8/version"
-	"github.com/kubernetes/minikube/pkg/minikube/config"
-	"github.com/minishift/minishift/pkg/minikube/update"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	cmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
@@ -39,12 +45,50 @@ const (
 	lastUpdateCheck    = "last_update_check"
 	hiddenFolder       = "/.fabric8/"
 	versionConsoleFlag = "version-console"
+
+	updateChannelFlag = "update-channel"
+	updateSourceFlag  = "update-source"
+
+	updateReminderPeriod = 24 * time.Hour
 )
 
 func runHelp(cmd *cobra.Command, args []string) {
 	cmd.Help()
 }
 
+// reminderDue reports whether it's been longer than period since
+// lastCheckFile was last touched, i.e. whether it's time to check for an
+// update again.
+func reminderDue(lastCheckFile string, period time.Duration) bool {
+	info, err := os.Stat(lastCheckFile)
+	if err != nil {
+		return true
+	}
+	return time.Since(info.ModTime()) > period
+}
+
+// touchFile records that the update check ran just now, so reminderDue
+// doesn't fire again until the next period elapses.
+func touchFile(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		ioutil.WriteFile(path, []byte{}, 0600)
+	}
+}
+
+// updateSourceFor resolves the --update-source override into an
+// selfupdate.UpdateSource, defaulting to GitHub releases when unset.
+func updateSourceFor(source string) selfupdate.UpdateSource {
+	switch {
+	case source == "":
+		return selfupdate.NewGitHubSource(githubOrg, githubRepo)
+	case strings.HasPrefix(source, "oci://"):
+		return selfupdate.NewOCISource(strings.TrimPrefix(source, "oci://"), nil)
+	default:
+		return selfupdate.NewHTTPMirrorSource(source)
+	}
+}
+
 func main() {
 	cmds := &cobra.Command{
 		Use:   "gofabric8",
@@ -57,9 +101,25 @@ func main() {
 	cmds.PersistentFlags().String(versionConsoleFlag, "latest", "fabric8 version")
 	cmds.PersistentFlags().BoolP("yes", "y", false, "assume yes")
 	cmds.PersistentFlags().BoolP(batchFlag, "b", false, "Run in batch mode to avoid prompts. Can also be enabled via `export FABRIC8_BATCH=true`")
+	cmds.PersistentFlags().String(updateChannelFlag, "stable", "Which update channel to check for new gofabric8 versions: stable, beta or none")
+	cmds.PersistentFlags().String(updateSourceFlag, "", "Override the update source, e.g. an HTTP(S) mirror or oci://<repository> for air-gapped installs. Defaults to GitHub releases. Can also be set via `export FABRIC8_UPDATE_URL=...`")
 
 	f := cmdutil.NewFactory(nil)
 	f.BindFlags(cmds.PersistentFlags())
+	viper.BindPFlag(updateChannelFlag, cmds.PersistentFlags().Lookup(updateChannelFlag))
+	viper.BindPFlag(updateSourceFlag, cmds.PersistentFlags().Lookup(updateSourceFlag))
+	viper.BindEnv(updateSourceFlag, "FABRIC8_UPDATE_URL")
+
+	// Cancel commands.RootContext on Ctrl-C so the Check*/WaitFor* retry
+	// loops bail out cleanly instead of running through all their attempts.
+	ctx, cancel := context.WithCancel(context.Background())
+	commands.RootContext = ctx
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
 
 	updated := false
 	oldHandler := cmds.PersistentPreRun
@@ -75,6 +135,10 @@ func main() {
 			if batchFlag == "true" {
 				batch = true
 			}
+			assumeYes := false
+			if yes := cmds.Flags().Lookup("yes"); yes != nil {
+				assumeYes = yes.Value.String() == "true"
+			}
 
 			if !batch {
 				home := homedir.HomeDir()
@@ -90,10 +154,28 @@ func main() {
 				if err != nil {
 					util.Errorf("Unable to get local version %v", err)
 				}
-				viper.SetDefault(config.WantUpdateNotification, true)
-				viper.SetDefault(config.ReminderWaitPeriodInHours, 24)
-				update.MaybeUpdate(os.Stdout, githubOrg, githubRepo, binaryName, writeFileLocation+lastUpdateCheck, localVersion)
 
+				lastCheckFile := writeFileLocation + lastUpdateCheck
+				if reminderDue(lastCheckFile, updateReminderPeriod) {
+					channel := selfupdate.Channel(viper.GetString(updateChannelFlag))
+					source := updateSourceFor(viper.GetString(updateSourceFlag))
+					binaryPath, err := os.Executable()
+					confirmUpdate := func(newVersion semver.Version) bool {
+						if assumeYes {
+							return true
+						}
+						fmt.Fprintf(os.Stdout, "A new version of gofabric8 is available: %s. Update now? [y/N]: ", newVersion)
+						answer := ""
+						fmt.Scanln(&answer)
+						return answer == "y"
+					}
+					if err != nil {
+						util.Errorf("Unable to find the path of the running binary %v", err)
+					} else if err := selfupdate.MaybeUpdate(commands.RootContext, os.Stdout, source, channel, localVersion, binaryPath, confirmUpdate); err != nil {
+						util.Errorf("Unable to check for updates %v\n", err)
+					}
+					touchFile(lastCheckFile)
+				}
 			}
 		}
 		if oldHandler != nil {
@@ -107,10 +189,12 @@ func main() {
 	cmds.AddCommand(commands.NewCmdConsole(f))
 	cmds.AddCommand(commands.NewCmdDeploy(f))
 	cmds.AddCommand(commands.NewCmdDockerEnv(f))
+	cmds.AddCommand(commands.NewCmdExpose(f))
 	cmds.AddCommand(commands.NewCmdIngress(f))
 	cmds.AddCommand(commands.NewCmdInstall(f))
 	cmds.AddCommand(commands.NewCmdPackages(f))
 	cmds.AddCommand(commands.NewCmdPackageVersions(f))
+	cmds.AddCommand(commands.NewCmdPortForward(f))
 	cmds.AddCommand(commands.NewCmdPull(f))
 	cmds.AddCommand(commands.NewCmdRoutes(f))
 	cmds.AddCommand(commands.NewCmdRun(f))
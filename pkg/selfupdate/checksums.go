@@ -0,0 +1,68 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package selfupdate
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// signingKey is the ed25519 public key embedded at build time (via -ldflags
+// -X) that checksums.txt releases must be signed with. It is empty in dev
+// builds, in which case signature verification is skipped.
+var signingKey string
+
+// ParseAndVerifyChecksums verifies sig against checksums using signingKey (if
+// one is embedded) and returns the SHA-256 for name, e.g. "gofabric8-linux-amd64".
+func ParseAndVerifyChecksums(checksums []byte, sig []byte, name string) (string, error) {
+	if signingKey != "" {
+		key, err := decodeSigningKey(signingKey)
+		if err != nil {
+			return "", fmt.Errorf("invalid embedded signing key: %v", err)
+		}
+		if !ed25519.Verify(key, checksums, sig) {
+			return "", fmt.Errorf("checksums.txt signature verification failed")
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(checksums)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sum, entryName := fields[0], fields[1]
+		if entryName == name {
+			return sum, nil
+		}
+	}
+	return "", fmt.Errorf("no checksum entry found for %s", name)
+}
+
+func decodeSigningKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("signing key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
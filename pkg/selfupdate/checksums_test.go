@@ -0,0 +1,95 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package selfupdate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+const testChecksums = `deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef  gofabric8-linux-amd64
+cafebabecafebabecafebabecafebabecafebabecafebabecafebabecafebabe  gofabric8-darwin-amd64
+`
+
+func TestParseAndVerifyChecksumsNoEmbeddedKey(t *testing.T) {
+	old := signingKey
+	signingKey = ""
+	defer func() { signingKey = old }()
+
+	sum, err := ParseAndVerifyChecksums([]byte(testChecksums), []byte("not-a-real-signature"), "gofabric8-linux-amd64")
+	if err != nil {
+		t.Fatalf("unexpected error with no embedded key: %v", err)
+	}
+	if sum != "deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef" {
+		t.Errorf("sum = %q, want the linux-amd64 entry", sum)
+	}
+}
+
+func TestParseAndVerifyChecksumsMissingEntry(t *testing.T) {
+	old := signingKey
+	signingKey = ""
+	defer func() { signingKey = old }()
+
+	_, err := ParseAndVerifyChecksums([]byte(testChecksums), nil, "gofabric8-windows-amd64")
+	if err == nil {
+		t.Fatal("expected an error for a missing checksum entry, got nil")
+	}
+}
+
+func TestParseAndVerifyChecksumsValidSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate test key: %v", err)
+	}
+	sig := ed25519.Sign(priv, []byte(testChecksums))
+
+	old := signingKey
+	signingKey = hex.EncodeToString(pub)
+	defer func() { signingKey = old }()
+
+	sum, err := ParseAndVerifyChecksums([]byte(testChecksums), sig, "gofabric8-darwin-amd64")
+	if err != nil {
+		t.Fatalf("unexpected error with a valid signature: %v", err)
+	}
+	if sum != "cafebabecafebabecafebabecafebabecafebabecafebabecafebabecafebabe" {
+		t.Errorf("sum = %q, want the darwin-amd64 entry", sum)
+	}
+}
+
+func TestParseAndVerifyChecksumsInvalidSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate test key: %v", err)
+	}
+	sig := ed25519.Sign(priv, []byte("these are not the checksums you signed"))
+
+	old := signingKey
+	signingKey = hex.EncodeToString(pub)
+	defer func() { signingKey = old }()
+
+	if _, err := ParseAndVerifyChecksums([]byte(testChecksums), sig, "gofabric8-linux-amd64"); err == nil {
+		t.Fatal("expected a signature verification error, got nil")
+	}
+}
+
+func TestDecodeSigningKeyInvalidLength(t *testing.T) {
+	if _, err := decodeSigningKey(hex.EncodeToString([]byte("too-short"))); err == nil {
+		t.Fatal("expected an error for a key of the wrong length, got nil")
+	}
+}
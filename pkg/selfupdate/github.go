@@ -0,0 +1,114 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// GitHubSource resolves releases published on a GitHub repo, the same place
+// gofabric8 has always shipped its binaries.
+type GitHubSource struct {
+	Org  string
+	Repo string
+}
+
+// NewGitHubSource returns an UpdateSource backed by org/repo's GitHub releases.
+func NewGitHubSource(org, repo string) *GitHubSource {
+	return &GitHubSource{Org: org, Repo: repo}
+}
+
+func (g *GitHubSource) LatestVersion(ctx context.Context, channel Channel) (semver.Version, error) {
+	tag, err := g.latestTag(ctx, channel)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return semver.Parse(strings.TrimPrefix(tag, "v"))
+}
+
+func (g *GitHubSource) latestTag(ctx context.Context, channel Channel) (string, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", g.Org, g.Repo)
+	if channel == ChannelBeta {
+		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", g.Org, g.Repo)
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return "", fmt.Errorf("GitHub returned status %d for %s", res.StatusCode, url)
+	}
+
+	if channel == ChannelBeta {
+		var releases []struct {
+			TagName string `json:"tag_name"`
+		}
+		if err := decodeJSON(res.Body, &releases); err != nil {
+			return "", err
+		}
+		if len(releases) == 0 {
+			return "", fmt.Errorf("no releases found for %s/%s", g.Org, g.Repo)
+		}
+		return releases[0].TagName, nil
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := decodeJSON(res.Body, &release); err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+func (g *GitHubSource) Download(ctx context.Context, version semver.Version, dst string) (string, error) {
+	asset := fmt.Sprintf("%s-%s-%s", g.Repo, runtime.GOOS, runtime.GOARCH)
+	base := fmt.Sprintf("https://github.com/%s/%s/releases/download/v%s", g.Org, g.Repo, version)
+
+	checksums, err := fetch(ctx, base+"/checksums.txt")
+	if err != nil {
+		return "", err
+	}
+	sig, err := fetch(ctx, base+"/checksums.txt.sig")
+	if err != nil {
+		return "", err
+	}
+	sum, err := ParseAndVerifyChecksums(checksums, sig, asset)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := fetch(ctx, base+"/"+asset)
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(dst, body, 0644); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
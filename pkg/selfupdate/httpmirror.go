@@ -0,0 +1,74 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package selfupdate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// HTTPMirrorSource resolves releases from a plain HTTP(S) mirror, laid out
+// the same way as the GitHub release assets (a "latest" pointer file plus
+// per-version checksums.txt/checksums.txt.sig/<asset>), for air-gapped users
+// pointed at an internal Nexus/Artifactory via FABRIC8_UPDATE_URL.
+type HTTPMirrorSource struct {
+	BaseURL string
+}
+
+// NewHTTPMirrorSource returns an UpdateSource backed by baseURL.
+func NewHTTPMirrorSource(baseURL string) *HTTPMirrorSource {
+	return &HTTPMirrorSource{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (h *HTTPMirrorSource) LatestVersion(ctx context.Context, channel Channel) (semver.Version, error) {
+	body, err := fetch(ctx, fmt.Sprintf("%s/%s/latest.txt", h.BaseURL, channel))
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return semver.Parse(strings.TrimPrefix(strings.TrimSpace(string(body)), "v"))
+}
+
+func (h *HTTPMirrorSource) Download(ctx context.Context, version semver.Version, dst string) (string, error) {
+	asset := fmt.Sprintf("gofabric8-%s-%s", runtime.GOOS, runtime.GOARCH)
+	base := fmt.Sprintf("%s/v%s", h.BaseURL, version)
+
+	checksums, err := fetch(ctx, base+"/checksums.txt")
+	if err != nil {
+		return "", err
+	}
+	sig, err := fetch(ctx, base+"/checksums.txt.sig")
+	if err != nil {
+		return "", err
+	}
+	sum, err := ParseAndVerifyChecksums(checksums, sig, asset)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := fetch(ctx, base+"/"+asset)
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(dst, body, 0644); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
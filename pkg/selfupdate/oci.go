@@ -0,0 +1,147 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/containers/image/docker"
+	"github.com/containers/image/types"
+)
+
+// OCISource resolves releases pulled as an artifact layer from an OCI
+// registry, for air-gapped clusters that mirror images but have no route
+// out to GitHub or an HTTP proxy. Versions are image tags on Repository;
+// each image has a single layer holding the gofabric8 binary for the
+// running GOOS/GOARCH and an io.fabric8.gofabric8.version config label.
+//
+// Alongside "v<version>" the repository must carry a
+// "v<version>-checksums" image (sole layer: checksums.txt) and a
+// "v<version>-checksums.sig" image (sole layer: the raw ed25519 signature
+// over it), mirroring the checksums.txt/.sig pair the GitHub and
+// HTTP mirror sources fetch, so the binary layer can be verified against an
+// externally-signed digest rather than trusting whatever bytes the registry
+// happens to hand back.
+type OCISource struct {
+	Repository string
+	SysCtx     *types.SystemContext
+}
+
+// NewOCISource returns an UpdateSource backed by an OCI repository, e.g.
+// "registry.example.com/fabric8io/gofabric8".
+func NewOCISource(repository string, sysCtx *types.SystemContext) *OCISource {
+	return &OCISource{Repository: repository, SysCtx: sysCtx}
+}
+
+func (o *OCISource) refFor(tag string) (types.ImageReference, error) {
+	return docker.ParseReference(fmt.Sprintf("//%s:%s", o.Repository, tag))
+}
+
+func (o *OCISource) LatestVersion(ctx context.Context, channel Channel) (semver.Version, error) {
+	ref, err := o.refFor(string(channel))
+	if err != nil {
+		return semver.Version{}, err
+	}
+	img, err := ref.NewImage(o.SysCtx)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	defer img.Close()
+
+	config, err := img.OCIConfig()
+	if err != nil {
+		return semver.Version{}, err
+	}
+	version, ok := config.Config.Labels["io.fabric8.gofabric8.version"]
+	if !ok {
+		return semver.Version{}, fmt.Errorf("image %s:%s has no io.fabric8.gofabric8.version label", o.Repository, channel)
+	}
+	return semver.Parse(strings.TrimPrefix(version, "v"))
+}
+
+func (o *OCISource) Download(ctx context.Context, version semver.Version, dst string) (string, error) {
+	asset := fmt.Sprintf("gofabric8-%s-%s", runtime.GOOS, runtime.GOARCH)
+
+	checksums, err := o.pullSoleLayer("v" + version.String() + "-checksums")
+	if err != nil {
+		return "", fmt.Errorf("unable to pull checksums.txt: %v", err)
+	}
+	sig, err := o.pullSoleLayer("v" + version.String() + "-checksums.sig")
+	if err != nil {
+		return "", fmt.Errorf("unable to pull checksums.txt.sig: %v", err)
+	}
+	expectedSum, err := ParseAndVerifyChecksums(checksums, sig, asset)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := o.pullSoleLayer("v" + version.String())
+	if err != nil {
+		return "", fmt.Errorf("unable to pull binary layer: %v", err)
+	}
+
+	h := sha256.New()
+	h.Write(body)
+	actualSum := hex.EncodeToString(h.Sum(nil))
+	if actualSum != expectedSum {
+		return "", fmt.Errorf("checksum mismatch: checksums.txt says %s, binary layer hashes to %s", expectedSum, actualSum)
+	}
+
+	if err := ioutil.WriteFile(dst, body, 0755); err != nil {
+		return "", err
+	}
+	return expectedSum, nil
+}
+
+// pullSoleLayer pulls the tagged image and returns the bytes of its single
+// layer, the convention this source uses for publishing a binary or a
+// checksums.txt/.sig file as an OCI artifact.
+func (o *OCISource) pullSoleLayer(tag string) ([]byte, error) {
+	ref, err := o.refFor(tag)
+	if err != nil {
+		return nil, err
+	}
+	src, err := ref.NewImageSource(o.SysCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	img, err := ref.NewImage(o.SysCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Close()
+
+	layers := img.LayerInfos()
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("image %s:%s has no layers", o.Repository, tag)
+	}
+	blob, _, err := src.GetBlob(layers[len(layers)-1])
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	return ioutil.ReadAll(blob)
+}
@@ -0,0 +1,121 @@
+/**
+ * Copyright (C) 2015 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package selfupdate provides a pluggable backend for gofabric8's
+// self-update check, so air-gapped users can point the binary at an
+// internal mirror instead of GitHub releases.
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/blang/semver"
+)
+
+// Channel selects which release stream an UpdateSource should resolve
+// against, set via the --update-channel flag.
+type Channel string
+
+const (
+	ChannelStable Channel = "stable"
+	ChannelBeta   Channel = "beta"
+	ChannelNone   Channel = "none"
+)
+
+// UpdateSource resolves and downloads gofabric8 binary releases. Each of
+// GitHub releases, a plain HTTP(S) mirror and an OCI registry channel
+// implement this the same way so MaybeUpdate doesn't need to know which
+// one it's talking to.
+type UpdateSource interface {
+	// LatestVersion returns the newest version available on this channel.
+	LatestVersion(ctx context.Context, channel Channel) (semver.Version, error)
+
+	// Download fetches the binary for version into dst and returns its
+	// SHA-256 checksum as verified against the source's checksums.txt.
+	Download(ctx context.Context, version semver.Version, dst string) (sha256sum string, err error)
+}
+
+// MaybeUpdate checks source for a newer version than localVersion and, if
+// one is found and the user accepts it, downloads and verifies it before
+// atomically replacing the running binary at dst.
+func MaybeUpdate(ctx context.Context, out io.Writer, source UpdateSource, channel Channel, localVersion semver.Version, dst string, confirm func(newVersion semver.Version) bool) error {
+	if channel == ChannelNone {
+		return nil
+	}
+
+	latest, err := source.LatestVersion(ctx, channel)
+	if err != nil {
+		return fmt.Errorf("unable to look up latest version: %v", err)
+	}
+	if !latest.GT(localVersion) {
+		return nil
+	}
+	if confirm != nil && !confirm(latest) {
+		return nil
+	}
+
+	tmpfile, err := ioutil.TempFile(filepath.Dir(dst), "gofabric8-update")
+	if err != nil {
+		return err
+	}
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	sum, err := source.Download(ctx, latest, tmpfile.Name())
+	if err != nil {
+		return fmt.Errorf("unable to download version %s: %v", latest, err)
+	}
+	if err := verifyChecksum(tmpfile.Name(), sum); err != nil {
+		return fmt.Errorf("checksum verification failed for version %s: %v", latest, err)
+	}
+
+	if err := os.Chmod(tmpfile.Name(), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpfile.Name(), dst); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Updated gofabric8 from %s to %s\n", localVersion, latest)
+	return nil
+}
+
+// verifyChecksum recomputes the SHA-256 of path and compares it against the
+// checksum the UpdateSource reported for the matching checksums.txt entry.
+func verifyChecksum(path string, expectedSha256 string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expectedSha256 {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedSha256, actual)
+	}
+	return nil
+}